@@ -0,0 +1,119 @@
+package database
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/vault/logical"
+)
+
+// Driver type identifiers accepted by config/connection's "type" field.
+const (
+	driverPostgres = "postgres"
+	driverRedshift = "redshift"
+)
+
+const redshiftMaxUsernameLength = 63
+
+// driver captures the handful of behaviors that differ between a standard
+// PostgreSQL connection and a Redshift one. Redshift is Postgres-wire
+// compatible, but has no ROLE semantics (CREATE USER only), accepts only a
+// subset of CREATE ROLE's options on CREATE USER, and has its own rules for
+// usernames and password hashing.
+type driver interface {
+	// CreateStatement renders the CREATE ROLE/USER statement synthesized
+	// from a role's structured attributes.
+	CreateStatement(r *roleEntry) string
+
+	// DefaultRenewalSQL is used whenever a role does not supply its own
+	// renewal_sql.
+	DefaultRenewalSQL() string
+
+	// DefaultRevocationSQL is used whenever a role does not supply its own
+	// revocation_sql.
+	DefaultRevocationSQL() string
+
+	// FormatUsername adapts a generated username to this driver's
+	// identifier constraints.
+	FormatUsername(name string) string
+}
+
+// driverFor resolves the driver for a connection's configured type,
+// defaulting to postgres for an empty or unrecognized value.
+func driverFor(connectionType string) driver {
+	if connectionType == driverRedshift {
+		return redshiftDriver{}
+	}
+	return postgresDriver{}
+}
+
+type postgresDriver struct{}
+
+func (postgresDriver) CreateStatement(r *roleEntry) string {
+	return fmt.Sprintf(`CREATE ROLE {{name}} WITH %s;`, roleOptions(r))
+}
+
+func (postgresDriver) DefaultRenewalSQL() string {
+	return ""
+}
+
+func (postgresDriver) DefaultRevocationSQL() string {
+	return `DROP ROLE IF EXISTS {{name}};`
+}
+
+func (postgresDriver) FormatUsername(name string) string {
+	return name
+}
+
+type redshiftDriver struct{}
+
+func (redshiftDriver) CreateStatement(r *roleEntry) string {
+	return fmt.Sprintf(`CREATE USER {{name}} WITH %s;`, redshiftRoleOptions(r))
+}
+
+func (redshiftDriver) DefaultRenewalSQL() string {
+	// Redshift has no lease semantics of its own: the user simply remains
+	// valid until VALID UNTIL, so renewal must explicitly push it out.
+	return `ALTER USER {{name}} VALID UNTIL {{expiration}};`
+}
+
+func (redshiftDriver) DefaultRevocationSQL() string {
+	return `DROP USER IF EXISTS {{name}};`
+}
+
+func (redshiftDriver) FormatUsername(name string) string {
+	name = strings.ToLower(name)
+	if len(name) > redshiftMaxUsernameLength {
+		name = name[:redshiftMaxUsernameLength]
+	}
+	return name
+}
+
+// driverForDB resolves the driver configured for a connection name,
+// defaulting to postgres if the connection has not been configured (or no
+// longer exists).
+func (b *backend) driverForDB(s logical.Storage, dbName string) (driver, error) {
+	cfg, err := b.Connection(s, dbName)
+	if err != nil {
+		return nil, err
+	}
+	if cfg == nil {
+		return driverFor(""), nil
+	}
+	return driverFor(cfg.Type), nil
+}
+
+// driverForRole resolves the driver for the connection a role targets.
+func (b *backend) driverForRole(s logical.Storage, r *roleEntry) (driver, error) {
+	return b.driverForDB(s, r.DBName)
+}
+
+// md5Password computes the Redshift/Postgres "md5" pre-hashed password
+// form, "md5" + md5(password+username), so the plaintext password never
+// has to traverse the wire to the database.
+func md5Password(username, password string) string {
+	sum := md5.Sum([]byte(password + username))
+	return "md5" + hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,102 @@
+package database
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRedshiftDriver_FormatUsername(t *testing.T) {
+	d := redshiftDriver{}
+
+	got := d.FormatUsername("Vault-Token-ABCDEF")
+	if got != "vault-token-abcdef" {
+		t.Fatalf("expected lowercased username, got: %s", got)
+	}
+
+	long := ""
+	for i := 0; i < 100; i++ {
+		long += "a"
+	}
+	got = d.FormatUsername(long)
+	if len(got) != redshiftMaxUsernameLength {
+		t.Fatalf("expected username truncated to %d chars, got %d", redshiftMaxUsernameLength, len(got))
+	}
+}
+
+func TestRedshiftDriver_CreateStatementUsesCreateUser(t *testing.T) {
+	role := &roleEntry{Login: true, Inherit: true, ConnectionLimit: -1}
+
+	stmt := redshiftDriver{}.CreateStatement(role)
+	if stmt[:len("CREATE USER")] != "CREATE USER" {
+		t.Fatalf("expected CREATE USER statement, got: %s", stmt)
+	}
+
+	stmt = postgresDriver{}.CreateStatement(role)
+	if stmt[:len("CREATE ROLE")] != "CREATE ROLE" {
+		t.Fatalf("expected CREATE ROLE statement, got: %s", stmt)
+	}
+}
+
+// TestRedshiftDriver_CreateStatementOmitsUnsupportedOptions guards against
+// CreateStatement emitting PostgreSQL-only CREATE ROLE options that
+// Redshift's CREATE USER rejects (LOGIN/NOLOGIN, SUPERUSER, CREATEROLE,
+// INHERIT, REPLICATION, BYPASSRLS).
+func TestRedshiftDriver_CreateStatementOmitsUnsupportedOptions(t *testing.T) {
+	role := &roleEntry{
+		Login:           true,
+		Superuser:       true,
+		CreateRole:      true,
+		Inherit:         true,
+		Replication:     true,
+		BypassRLS:       true,
+		CreateDB:        true,
+		ConnectionLimit: -1,
+		ValidUntil:      "2030-01-01T00:00:00Z",
+	}
+
+	stmt := redshiftDriver{}.CreateStatement(role)
+
+	for _, unsupported := range []string{"LOGIN", "NOLOGIN", "SUPERUSER", "CREATEROLE", "INHERIT", "REPLICATION", "BYPASSRLS"} {
+		if strings.Contains(stmt, unsupported) {
+			t.Fatalf("expected Redshift CREATE USER to omit %q, got: %s", unsupported, stmt)
+		}
+	}
+
+	for _, wanted := range []string{"PASSWORD {{password}}", "CREATEDB", "VALID UNTIL {{valid_until}}", "CONNECTION LIMIT UNLIMITED"} {
+		if !strings.Contains(stmt, wanted) {
+			t.Fatalf("expected Redshift CREATE USER to contain %q, got: %s", wanted, stmt)
+		}
+	}
+}
+
+// TestRedshiftDriver_CreateStatementConnectionLimit guards against a
+// negative ConnectionLimit (PostgreSQL's "no limit" convention, and this
+// role field's default) being rendered as "CONNECTION LIMIT -1" -- a syntax
+// error on Redshift, which spells "no limit" as the keyword UNLIMITED.
+func TestRedshiftDriver_CreateStatementConnectionLimit(t *testing.T) {
+	cases := []struct {
+		limit int
+		want  string
+	}{
+		{limit: -1, want: "CONNECTION LIMIT UNLIMITED"},
+		{limit: 0, want: "CONNECTION LIMIT 0"},
+		{limit: 5, want: "CONNECTION LIMIT 5"},
+	}
+
+	for _, c := range cases {
+		role := &roleEntry{ConnectionLimit: c.limit}
+		stmt := redshiftDriver{}.CreateStatement(role)
+		if !strings.Contains(stmt, c.want) {
+			t.Fatalf("limit %d: expected %q, got: %s", c.limit, c.want, stmt)
+		}
+	}
+}
+
+func TestMD5Password(t *testing.T) {
+	// md5("foopassfoouser") == the well-known Postgres/Redshift md5 auth
+	// format: "md5" + hex digest of password concatenated with username.
+	got := md5Password("foouser", "foopass")
+	if got[:3] != "md5" || len(got) != 35 {
+		t.Fatalf("expected 'md5' + 32 hex chars, got: %s", got)
+	}
+}
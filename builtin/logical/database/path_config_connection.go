@@ -0,0 +1,157 @@
+package database
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+)
+
+func pathConfigConnection(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "config/connection/" + framework.GenericNameRegex("name"),
+		Fields: map[string]*framework.FieldSchema{
+			"name": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: "Name of the database connection.",
+			},
+
+			"connection_url": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: "The connection string used to connect to the database.",
+			},
+
+			"type": &framework.FieldSchema{
+				Type:    framework.TypeString,
+				Default: driverPostgres,
+				Description: `Database driver for this connection: "postgres" (default) or
+"redshift". Redshift is Postgres-wire-compatible but uses different DDL for
+role creation and lease renewal.`,
+			},
+
+			"max_open_connections": &framework.FieldSchema{
+				Type:        framework.TypeInt,
+				Default:     2,
+				Description: "Maximum number of open connections to the database.",
+			},
+
+			"allowed_roles": &framework.FieldSchema{
+				Type:        framework.TypeCommaStringSlice,
+				Description: "Comma-separated list of role names allowed to use this connection. If empty, any role may use it.",
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ReadOperation:   b.pathConnectionRead,
+			logical.UpdateOperation: b.pathConnectionWrite,
+			logical.DeleteOperation: b.pathConnectionDelete,
+		},
+
+		HelpSynopsis:    pathConfigConnectionHelpSyn,
+		HelpDescription: pathConfigConnectionHelpDesc,
+	}
+}
+
+// Connection loads the stored connection config for name, or nil if it has
+// not been configured.
+func (b *backend) Connection(s logical.Storage, name string) (*connectionConfig, error) {
+	entry, err := s.Get("config/connection/" + name)
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		return nil, nil
+	}
+
+	var result connectionConfig
+	if err := entry.DecodeJSON(&result); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+func (b *backend) pathConnectionRead(
+	req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	config, err := b.Connection(req.Storage, data.Get("name").(string))
+	if err != nil {
+		return nil, err
+	}
+	if config == nil {
+		return nil, nil
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			// connection_url is intentionally omitted: it may contain
+			// credentials and is not readable once set.
+			"type":                 config.Type,
+			"max_open_connections": config.MaxOpenConnections,
+			"allowed_roles":        config.AllowedRoles,
+		},
+	}, nil
+}
+
+func (b *backend) pathConnectionWrite(
+	req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	name := data.Get("name").(string)
+
+	driverType := data.Get("type").(string)
+	switch driverType {
+	case driverPostgres, driverRedshift:
+	default:
+		return logical.ErrorResponse(fmt.Sprintf(
+			"unsupported connection type %q: must be %q or %q", driverType, driverPostgres, driverRedshift)), nil
+	}
+
+	config := &connectionConfig{
+		ConnectionURL:      data.Get("connection_url").(string),
+		Type:               driverType,
+		MaxOpenConnections: data.Get("max_open_connections").(int),
+		AllowedRoles:       data.Get("allowed_roles").([]string),
+	}
+
+	entry, err := logical.StorageEntryJSON("config/connection/"+name, config)
+	if err != nil {
+		return nil, err
+	}
+	if err := req.Storage.Put(entry); err != nil {
+		return nil, err
+	}
+
+	return nil, nil
+}
+
+func (b *backend) pathConnectionDelete(
+	req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	err := req.Storage.Delete("config/connection/" + data.Get("name").(string))
+	if err != nil {
+		return nil, err
+	}
+
+	return nil, nil
+}
+
+type connectionConfig struct {
+	ConnectionURL      string   `json:"connection_url"`
+	Type               string   `json:"type"`
+	MaxOpenConnections int      `json:"max_open_connections"`
+	AllowedRoles       []string `json:"allowed_roles"`
+}
+
+const pathConfigConnectionHelpSyn = `
+Configure the connection string to talk to PostgreSQL or Redshift.
+`
+
+const pathConfigConnectionHelpDesc = `
+This path configures the connection string used to connect to the
+database for a given connection name. "type" selects the driver: "postgres"
+(the default) or "redshift". Redshift is wire-compatible with PostgreSQL
+but diverges in DDL -- roles attached to a Redshift connection get
+CREATE USER instead of CREATE ROLE, and "ALTER USER ... VALID UNTIL" as the
+default lease-renewal statement, since Redshift has no true lease semantics.
+
+"allowed_roles" restricts which roles/<name> may target this connection,
+so multi-tenant operators can isolate which teams' roles may reach which
+databases. If left empty, any role may use this connection.
+`
@@ -0,0 +1,160 @@
+package database
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/vault/helper/strutil"
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+)
+
+func pathCredsCreate(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "creds/" + framework.GenericNameRegex("name"),
+
+		Fields: map[string]*framework.FieldSchema{
+			"name": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: "Name of the role.",
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ReadOperation: b.pathCredsCreateRead,
+		},
+
+		HelpSynopsis:    pathCredsCreateHelpSyn,
+		HelpDescription: pathCredsCreateHelpDesc,
+	}
+}
+
+// pathCredsCreateRead generates a new username/password for role "name",
+// runs the role's CreationSQL against its connection to actually create the
+// database user, and returns the credentials as a leased secret.
+func (b *backend) pathCredsCreateRead(
+	req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	name := data.Get("name").(string)
+
+	role, err := b.Role(req.Storage, name)
+	if err != nil {
+		return nil, err
+	}
+	if role == nil {
+		return logical.ErrorResponse(fmt.Sprintf("unknown role: %s", name)), nil
+	}
+
+	dbconn, err := b.DBConnection(req.Storage, role.DBName)
+	if err != nil {
+		return nil, err
+	}
+
+	drv, err := b.driverForRole(req.Storage, role)
+	if err != nil {
+		return nil, err
+	}
+
+	// FormatUsername applies each driver's identifier constraints (e.g.
+	// Redshift's 63-character, lowercase-only usernames) to the generated
+	// name before it is ever used in DDL or returned to the caller.
+	username, err := generateUsername(req.DisplayName)
+	if err != nil {
+		return nil, err
+	}
+	username = drv.FormatUsername(username)
+
+	password, err := generatePassword()
+	if err != nil {
+		return nil, err
+	}
+
+	sqlPassword := password
+	if role.MD5Password {
+		sqlPassword = md5Password(username, password)
+	}
+
+	lease := b.System().DefaultLeaseTTL()
+	expiration := time.Now().Add(lease).Format("2006-01-02 15:04:05-0700")
+
+	for _, query := range strutil.ParseArbitraryStringSlice(role.CreationSQL(drv), ";") {
+		query = strings.TrimSpace(query)
+		if len(query) == 0 {
+			continue
+		}
+
+		stmt, err := dbconn.Prepare(Query(query, map[string]string{
+			"name":        username,
+			"password":    sqlPassword,
+			"expiration":  expiration,
+			"valid_until": role.ValidUntil,
+		}))
+		if err != nil {
+			return nil, err
+		}
+		defer stmt.Close()
+
+		if _, err := stmt.Exec(); err != nil {
+			return nil, err
+		}
+	}
+
+	resp := b.Secret(SecretCredsType).Response(map[string]interface{}{
+		"username": username,
+		"password": password,
+	}, map[string]interface{}{
+		"username": username,
+		"role":     name,
+		"db_name":  role.DBName,
+	})
+	resp.Secret.TTL = lease
+
+	return resp, nil
+}
+
+// generateUsername produces a username seeded with the requesting token's
+// display name, so a generated database user is traceable back to whoever
+// requested it. The result is still run through the driver's FormatUsername
+// before use, since "vault" and the display name may contain characters or
+// length a given database's usernames can't accept.
+func generateUsername(displayName string) (string, error) {
+	if displayName == "" {
+		displayName = "vault"
+	}
+
+	suffix := make([]byte, 10)
+	if _, err := rand.Read(suffix); err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("v-%s-%s", displayName, hex.EncodeToString(suffix)), nil
+}
+
+// generatePassword produces a random password for a generated user.
+func generatePassword() (string, error) {
+	raw := make([]byte, 24)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(raw), nil
+}
+
+const pathCredsCreateHelpSyn = `
+Request database credentials for a role.
+`
+
+const pathCredsCreateHelpDesc = `
+This path creates a database username and password for the named role. The
+username is generated from the requesting token's display name and then
+passed through the role's connection driver (see config/connection's
+"type"), which enforces that driver's identifier constraints -- for
+Redshift, lowercasing it and truncating it to 63 characters.
+
+Reading from creds/<name> runs the role's CreationSQL against its
+connection to create the user, and returns the generated "username" and
+"password" as a leased secret. Revoking or renewing the lease runs the
+role's revocation_sql/renewal_sql (or the connection driver's defaults)
+against the generated username.
+`
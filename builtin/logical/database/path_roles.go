@@ -7,6 +7,8 @@ import (
 	"github.com/hashicorp/vault/helper/strutil"
 	"github.com/hashicorp/vault/logical"
 	"github.com/hashicorp/vault/logical/framework"
+	"github.com/lib/pq"
+	"github.com/mitchellh/mapstructure"
 )
 
 func pathListRoles(b *backend) *framework.Path {
@@ -35,24 +37,115 @@ func pathRoles(b *backend) *framework.Path {
 				Type:        framework.TypeString,
 				Description: "SQL string to create a user. See help for more info.",
 			},
-			
+
 			"db_name": &framework.FieldSchema{
 				Type:        framework.TypeString,
 				Description: "Name of the database associated with the role.",
 			},
+
+			"login": &framework.FieldSchema{
+				Type:        framework.TypeBool,
+				Default:     true,
+				Description: "Whether the role's user can log in. Maps to LOGIN/NOLOGIN.",
+			},
+
+			"superuser": &framework.FieldSchema{
+				Type:        framework.TypeBool,
+				Description: "Whether the role's user is a superuser. Maps to SUPERUSER/NOSUPERUSER.",
+			},
+
+			"create_database": &framework.FieldSchema{
+				Type:        framework.TypeBool,
+				Description: "Whether the role's user can create databases. Maps to CREATEDB/NOCREATEDB.",
+			},
+
+			"create_role": &framework.FieldSchema{
+				Type:        framework.TypeBool,
+				Description: "Whether the role's user can create other roles. Maps to CREATEROLE/NOCREATEROLE.",
+			},
+
+			"inherit": &framework.FieldSchema{
+				Type:        framework.TypeBool,
+				Default:     true,
+				Description: "Whether the role automatically inherits the privileges of roles it is a member of. Maps to INHERIT/NOINHERIT.",
+			},
+
+			"replication": &framework.FieldSchema{
+				Type:        framework.TypeBool,
+				Description: "Whether the role's user is a replication role. Maps to REPLICATION/NOREPLICATION.",
+			},
+
+			"bypass_row_level_security": &framework.FieldSchema{
+				Type:        framework.TypeBool,
+				Description: "Whether the role's user bypasses row-level security policies. Maps to BYPASSRLS/NOBYPASSRLS.",
+			},
+
+			"connection_limit": &framework.FieldSchema{
+				Type:        framework.TypeInt,
+				Default:     -1,
+				Description: "Maximum number of concurrent connections the role's user may make. -1 means no limit.",
+			},
+
+			"valid_until": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: "Timestamp after which the role's user password is no longer valid. Maps to VALID UNTIL.",
+			},
+
+			"encrypted_password": &framework.FieldSchema{
+				Type:        framework.TypeBool,
+				Description: "Whether the generated password should be stored encrypted. Maps to ENCRYPTED PASSWORD/PASSWORD.",
+			},
+
+			"md5_password": &framework.FieldSchema{
+				Type:        framework.TypeBool,
+				Description: "Transmit the password as 'md5' plus the hex MD5 of password+username, instead of the plaintext. Applies to any connection type; primarily useful for Redshift.",
+			},
+
+			"revocation_sql": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: "SQL string to revoke a user. See help for more info.",
+			},
+
+			"renewal_sql": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: "SQL string to renew a user's lease. See help for more info.",
+			},
+
+			"grants": &framework.FieldSchema{
+				Type: framework.TypeSlice,
+				Description: `Structured GRANT statements, as a list of objects shaped like
+{privileges: [...], on: "TABLES"|"SEQUENCES"|"SCHEMA"|"DATABASE", schema: "public", objects: ["*"], with_grant_option: false}.
+See help for more info.`,
+			},
 		},
 
 		Callbacks: map[logical.Operation]framework.OperationFunc{
 			logical.ReadOperation:   b.pathRoleRead,
-			logical.UpdateOperation: b.pathRoleCreate,
+			logical.CreateOperation: b.pathRoleCreate,
+			logical.UpdateOperation: b.pathRoleUpdate,
 			logical.DeleteOperation: b.pathRoleDelete,
 		},
 
+		ExistenceCheck: b.pathRoleExistenceCheck,
+
 		HelpSynopsis:    pathRoleHelpSyn,
 		HelpDescription: pathRoleHelpDesc,
 	}
 }
 
+// pathRoleExistenceCheck tells the framework whether roles/<name> already
+// has a stored role, so a write request is routed to pathRoleCreate (new
+// role) or pathRoleUpdate (existing role) instead of always landing on
+// UpdateOperation.
+func (b *backend) pathRoleExistenceCheck(
+	req *logical.Request, data *framework.FieldData) (bool, error) {
+	role, err := b.Role(req.Storage, data.Get("name").(string))
+	if err != nil {
+		return false, err
+	}
+	return role != nil, nil
+}
+
 func (b *backend) Role(s logical.Storage, n string) (*roleEntry, error) {
 	entry, err := s.Get("role/" + n)
 	if err != nil {
@@ -92,7 +185,22 @@ func (b *backend) pathRoleRead(
 
 	return &logical.Response{
 		Data: map[string]interface{}{
-			"sql": role.SQL,
+			"sql":                       role.SQL,
+			"db_name":                   role.DBName,
+			"login":                     role.Login,
+			"superuser":                 role.Superuser,
+			"create_database":           role.CreateDB,
+			"create_role":               role.CreateRole,
+			"inherit":                   role.Inherit,
+			"replication":               role.Replication,
+			"bypass_row_level_security": role.BypassRLS,
+			"connection_limit":          role.ConnectionLimit,
+			"valid_until":               role.ValidUntil,
+			"encrypted_password":        role.EncryptedPassword,
+			"md5_password":              role.MD5Password,
+			"revocation_sql":            role.RevocationSQL,
+			"renewal_sql":               role.RenewalSQL,
+			"grants":                    role.Grants,
 		},
 	}, nil
 }
@@ -110,40 +218,206 @@ func (b *backend) pathRoleList(
 func (b *backend) pathRoleCreate(
 	req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
 	name := data.Get("name").(string)
-	sqlstmt := data.Get("sql").(string)
-	dbname := data.Get("db_name").(string)
-	
-	// Get our connection
-	dbconn, err := b.DBConnection(req.Storage, dbname)
-	if dbconn == nil {
-		b.logger.Trace("[TRACE] b.dbs[%s] is not connected.", dbname)
+
+	role := &roleEntry{}
+	for _, field := range roleScalarFields {
+		applyRoleField(role, data, field)
+	}
+	role.HasAttributes = roleHasAttributes(data.Raw)
+
+	grants, err := parseGrants(data.Get("grants").([]interface{}))
+	if err != nil {
+		return logical.ErrorResponse(fmt.Sprintf("Error parsing grants: %s", err)), nil
+	}
+	role.Grants = grants
+	applyGrantRevokes(role)
+
+	return b.validateAndStoreRole(req, name, role)
+}
+
+// pathRoleUpdate merges only the fields present on the request into the
+// role's existing entry, so updating one field (e.g. "db_name") does not
+// discard the others (e.g. "sql").
+func (b *backend) pathRoleUpdate(
+	req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	name := data.Get("name").(string)
+
+	role, err := b.Role(req.Storage, name)
+	if err != nil {
 		return nil, err
 	}
+	if role == nil {
+		return logical.ErrorResponse(fmt.Sprintf("no role found to update: %s", name)), nil
+	}
 
-	// Test the query by trying to prepare it
-	for _, query := range strutil.ParseArbitraryStringSlice(sqlstmt, ";") {
-		query = strings.TrimSpace(query)
-		if len(query) == 0 {
-			continue
+	for _, field := range roleScalarFields {
+		if _, ok := data.Raw[field]; ok {
+			applyRoleField(role, data, field)
 		}
-		
-		stmt, err := dbconn.Prepare(Query(query, map[string]string{
-			"name":       "foo",
-			"password":   "bar",
-			"expiration": "",
-		}))
+	}
+	if roleHasAttributes(data.Raw) {
+		role.HasAttributes = true
+	}
+
+	if _, ok := data.Raw["grants"]; ok {
+		grants, err := parseGrants(data.Get("grants").([]interface{}))
 		if err != nil {
-			return logical.ErrorResponse(fmt.Sprintf(
-				"Error testing query: %s", err)), nil
+			return logical.ErrorResponse(fmt.Sprintf("Error parsing grants: %s", err)), nil
+		}
+		role.Grants = grants
+		applyGrantRevokes(role)
+	}
+
+	return b.validateAndStoreRole(req, name, role)
+}
+
+// roleScalarFields lists every roles/<name> field except "name" and
+// "grants", which are handled separately because they need custom parsing.
+var roleScalarFields = []string{
+	"sql",
+	"db_name",
+	"login",
+	"superuser",
+	"create_database",
+	"create_role",
+	"inherit",
+	"replication",
+	"bypass_row_level_security",
+	"connection_limit",
+	"valid_until",
+	"encrypted_password",
+	"md5_password",
+	"revocation_sql",
+	"renewal_sql",
+}
+
+// applyRoleField copies a single named field from the request data onto
+// role.
+func applyRoleField(role *roleEntry, data *framework.FieldData, field string) {
+	switch field {
+	case "sql":
+		role.SQL = data.Get("sql").(string)
+	case "db_name":
+		role.DBName = data.Get("db_name").(string)
+	case "login":
+		role.Login = data.Get("login").(bool)
+	case "superuser":
+		role.Superuser = data.Get("superuser").(bool)
+	case "create_database":
+		role.CreateDB = data.Get("create_database").(bool)
+	case "create_role":
+		role.CreateRole = data.Get("create_role").(bool)
+	case "inherit":
+		role.Inherit = data.Get("inherit").(bool)
+	case "replication":
+		role.Replication = data.Get("replication").(bool)
+	case "bypass_row_level_security":
+		role.BypassRLS = data.Get("bypass_row_level_security").(bool)
+	case "connection_limit":
+		role.ConnectionLimit = data.Get("connection_limit").(int)
+	case "valid_until":
+		role.ValidUntil = data.Get("valid_until").(string)
+	case "encrypted_password":
+		role.EncryptedPassword = data.Get("encrypted_password").(bool)
+	case "md5_password":
+		role.MD5Password = data.Get("md5_password").(bool)
+	case "revocation_sql":
+		role.RevocationSQL = data.Get("revocation_sql").(string)
+	case "renewal_sql":
+		role.RenewalSQL = data.Get("renewal_sql").(string)
+	}
+}
+
+// applyGrantRevokes recomputes role.GrantRevocationSQL from role.Grants.
+// It replaces the previous value outright rather than folding onto it, so
+// calling this again after grants change (e.g. on a second update) does not
+// accumulate stale REVOKEs from the prior set of grants.
+func applyGrantRevokes(role *roleEntry) {
+	role.GrantRevocationSQL = compileGrantRevokes(role.Grants)
+}
+
+// EffectiveRevocationSQL returns the SQL actually run to revoke a lease for
+// this role: the auto-generated REVOKEs for its current Grants, followed by
+// RevocationSQL if the role has its own, or driverDefault otherwise, so
+// revoking a lease undoes exactly what creation did -- including the
+// CREATE ROLE/USER itself -- without needing the two to be combined and
+// persisted as a single accumulating string.
+func (r *roleEntry) EffectiveRevocationSQL(driverDefault string) string {
+	var parts []string
+	if sql := strings.TrimSpace(r.GrantRevocationSQL); sql != "" {
+		parts = append(parts, sql)
+	}
+
+	userSQL := strings.TrimSpace(r.RevocationSQL)
+	if userSQL == "" {
+		userSQL = strings.TrimSpace(driverDefault)
+	}
+	if userSQL != "" {
+		parts = append(parts, userSQL)
+	}
+
+	return strings.Join(parts, "\n")
+}
+
+// validateAndStoreRole checks that role's database connection is allowed to
+// host it, validates its creation/revocation/renewal SQL against that
+// connection's driver, and persists it.
+func (b *backend) validateAndStoreRole(
+	req *logical.Request, name string, role *roleEntry) (*logical.Response, error) {
+	connConfig, err := b.Connection(req.Storage, role.DBName)
+	if err != nil {
+		return nil, err
+	}
+	if connConfig != nil && len(connConfig.AllowedRoles) > 0 &&
+		!strutil.StrListContains(connConfig.AllowedRoles, name) {
+		return logical.ErrorResponse(fmt.Sprintf(
+			"connection %q does not allow role %q", role.DBName, name)), nil
+	}
+
+	// Get our connection
+	dbconn, err := b.DBConnection(req.Storage, role.DBName)
+	if dbconn == nil {
+		b.logger.Trace("[TRACE] b.dbs[%s] is not connected.", role.DBName)
+		return nil, err
+	}
+
+	var connType string
+	if connConfig != nil {
+		connType = connConfig.Type
+	}
+	drv := driverFor(connType)
+
+	dummyPassword := "bar"
+	if role.MD5Password {
+		dummyPassword = md5Password("foo", dummyPassword)
+	}
+
+	// Test the creation, revocation, and renewal statements by trying to
+	// prepare each of them against the role's driver.
+	statementSets := []string{role.CreationSQL(drv), role.EffectiveRevocationSQL(drv.DefaultRevocationSQL()), role.RenewalSQL}
+	for _, statements := range statementSets {
+		for _, query := range strutil.ParseArbitraryStringSlice(statements, ";") {
+			query = strings.TrimSpace(query)
+			if len(query) == 0 {
+				continue
+			}
+
+			stmt, err := dbconn.Prepare(Query(query, map[string]string{
+				"name":        "foo",
+				"password":    dummyPassword,
+				"expiration":  "",
+				"valid_until": role.ValidUntil,
+			}))
+			if err != nil {
+				return logical.ErrorResponse(fmt.Sprintf(
+					"Error testing query: %s", err)), nil
+			}
+			stmt.Close()
 		}
-		stmt.Close()
 	}
 
 	// Store it
-	entry, err := logical.StorageEntryJSON("role/"+name, &roleEntry{
-		SQL:    sqlstmt,
-		DBName: dbname,
-	})
+	entry, err := logical.StorageEntryJSON("role/"+name, role)
 	if err != nil {
 		return nil, err
 	}
@@ -154,12 +428,324 @@ func (b *backend) pathRoleCreate(
 	return nil, nil
 }
 
+// roleAttributeFields lists the request fields that make up the structured,
+// declarative role-attribute schema. Their presence on a request (as opposed
+// to their zero value) is what triggers CREATE ROLE synthesis.
+var roleAttributeFields = []string{
+	"login",
+	"superuser",
+	"create_database",
+	"create_role",
+	"inherit",
+	"replication",
+	"bypass_row_level_security",
+	"connection_limit",
+	"valid_until",
+	"encrypted_password",
+}
+
+// roleHasAttributes reports whether the raw request data included any of the
+// structured role-attribute fields, as opposed to relying solely on "sql".
+func roleHasAttributes(raw map[string]interface{}) bool {
+	for _, field := range roleAttributeFields {
+		if _, ok := raw[field]; ok {
+			return true
+		}
+	}
+
+	return false
+}
+
 type roleEntry struct {
 	// SQL statement for the role
-	SQL    string `json:"sql"`
-	
+	SQL string `json:"sql"`
+
 	// Name of database that will use the role
 	DBName string `json:"db_name"`
+
+	// HasAttributes records whether this role was defined using the
+	// structured attribute schema below, so CreationSQL knows whether to
+	// synthesize a CREATE ROLE statement.
+	HasAttributes bool `json:"has_attributes"`
+
+	// Structured PostgreSQL role attributes, mirroring the attribute set
+	// used by the Terraform PostgreSQL provider. When HasAttributes is set,
+	// these are compiled into a CREATE ROLE statement that runs before SQL.
+	Login             bool   `json:"login"`
+	Superuser         bool   `json:"superuser"`
+	CreateDB          bool   `json:"create_database"`
+	CreateRole        bool   `json:"create_role"`
+	Inherit           bool   `json:"inherit"`
+	Replication       bool   `json:"replication"`
+	BypassRLS         bool   `json:"bypass_row_level_security"`
+	ConnectionLimit   int    `json:"connection_limit"`
+	ValidUntil        string `json:"valid_until"`
+	EncryptedPassword bool   `json:"encrypted_password"`
+
+	// MD5Password, if set, transmits the role's password as
+	// "md5" + md5(password+username) rather than plaintext. This is
+	// primarily for Redshift, which lacks PostgreSQL's native password
+	// encryption.
+	MD5Password bool `json:"md5_password"`
+
+	// RevocationSQL, if set, is run (with "{{name}}" substituted) in
+	// addition to the REVOKEs compiled from Grants when a lease for this
+	// role is revoked. See EffectiveRevocationSQL.
+	RevocationSQL string `json:"revocation_sql"`
+
+	// GrantRevocationSQL holds the REVOKE statements compiled from Grants.
+	// It is recomputed in full (not appended to) every time Grants is set,
+	// so re-applying it on a later update replaces the prior grants' REVOKEs
+	// instead of accumulating a stale copy alongside the new ones.
+	GrantRevocationSQL string `json:"grant_revocation_sql"`
+
+	// RenewalSQL, if set, is run (with "{{name}}" and "{{expiration}}"
+	// substituted) instead of the connection driver's default renewal SQL
+	// (a no-op for postgres) when a lease for this role is renewed.
+	RenewalSQL string `json:"renewal_sql"`
+
+	// Grants declares schema/table/sequence privileges to extend to the
+	// role's user as structured GRANT entries, compiled into SQL by
+	// CreationSQL. Their corresponding REVOKEs are compiled into
+	// GrantRevocationSQL, which EffectiveRevocationSQL folds in alongside
+	// RevocationSQL.
+	Grants []grantEntry `json:"grants"`
+}
+
+// grantEntry is a single declarative GRANT, compiled into a GRANT statement
+// for CreationSQL and a matching REVOKE statement for GrantRevocationSQL.
+type grantEntry struct {
+	// Privileges is the list of privileges to grant, e.g. "SELECT", "INSERT".
+	Privileges []string `json:"privileges" mapstructure:"privileges"`
+
+	// On is the kind of object the privileges apply to: "TABLES",
+	// "SEQUENCES", "SCHEMA", or "DATABASE".
+	On string `json:"on" mapstructure:"on"`
+
+	// Schema is the schema the grant applies within. Defaults to "public".
+	Schema string `json:"schema" mapstructure:"schema"`
+
+	// Objects names the tables, sequences, or databases to grant on. A
+	// single "*" means all objects of the given kind in Schema.
+	Objects []string `json:"objects" mapstructure:"objects"`
+
+	// WithGrantOption appends WITH GRANT OPTION to the GRANT statement.
+	WithGrantOption bool `json:"with_grant_option" mapstructure:"with_grant_option"`
+}
+
+func (g *grantEntry) schemaName() string {
+	if g.Schema == "" {
+		return "public"
+	}
+	return g.Schema
+}
+
+// target renders the "ON ..." clause shared by the GRANT and REVOKE
+// statements for this entry.
+func (g *grantEntry) target() string {
+	on := strings.ToUpper(g.On)
+	schema := pq.QuoteIdentifier(g.schemaName())
+
+	switch on {
+	case "SCHEMA":
+		return fmt.Sprintf("SCHEMA %s", schema)
+	case "DATABASE":
+		objects := make([]string, len(g.Objects))
+		for i, o := range g.Objects {
+			objects[i] = pq.QuoteIdentifier(o)
+		}
+		return fmt.Sprintf("DATABASE %s", strings.Join(objects, ", "))
+	case "TABLES", "SEQUENCES":
+		if len(g.Objects) == 1 && g.Objects[0] == "*" {
+			return fmt.Sprintf("ALL %s IN SCHEMA %s", on, schema)
+		}
+
+		// The plural keyword ("TABLES"/"SEQUENCES") is only valid in the
+		// "ALL ... IN SCHEMA" form above; naming specific objects requires
+		// the singular ("TABLE"/"SEQUENCE") keyword instead.
+		singular := strings.TrimSuffix(on, "S")
+		objects := make([]string, len(g.Objects))
+		for i, o := range g.Objects {
+			objects[i] = fmt.Sprintf("%s.%s", schema, pq.QuoteIdentifier(o))
+		}
+		return fmt.Sprintf("%s %s", singular, strings.Join(objects, ", "))
+	default:
+		return on
+	}
+}
+
+func (g *grantEntry) privilegeList() string {
+	privileges := make([]string, len(g.Privileges))
+	for i, p := range g.Privileges {
+		privileges[i] = strings.ToUpper(p)
+	}
+	return strings.Join(privileges, ", ")
+}
+
+func (g *grantEntry) grantSQL() string {
+	stmt := fmt.Sprintf("GRANT %s ON %s TO {{name}}", g.privilegeList(), g.target())
+	if g.WithGrantOption {
+		stmt += " WITH GRANT OPTION"
+	}
+	return stmt + ";"
+}
+
+func (g *grantEntry) revokeSQL() string {
+	return fmt.Sprintf("REVOKE %s ON %s FROM {{name}};", g.privilegeList(), g.target())
+}
+
+// parseGrants decodes the raw "grants" field data into structured
+// grantEntry values.
+func parseGrants(raw []interface{}) ([]grantEntry, error) {
+	grants := make([]grantEntry, 0, len(raw))
+	for _, r := range raw {
+		m, ok := r.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("each grant must be an object")
+		}
+
+		var grant grantEntry
+		if err := mapstructure.Decode(m, &grant); err != nil {
+			return nil, err
+		}
+		grants = append(grants, grant)
+	}
+
+	return grants, nil
+}
+
+// compileGrantRevokes joins the REVOKE statement for every grant, in
+// reverse order, so that revocation undoes creation.
+func compileGrantRevokes(grants []grantEntry) string {
+	statements := make([]string, len(grants))
+	for i, g := range grants {
+		statements[len(grants)-1-i] = g.revokeSQL()
+	}
+	return strings.Join(statements, "\n")
+}
+
+// CreationSQL returns the full statement sequence used to create a user for
+// this role on the given driver: the CREATE ROLE/USER statement synthesized
+// from the structured attributes (if any were supplied), followed by the
+// operator-supplied "sql" for advanced GRANTs or other setup, followed by
+// any declarative "grants".
+func (r *roleEntry) CreationSQL(d driver) string {
+	var statements []string
+
+	if r.HasAttributes {
+		statements = append(statements, d.CreateStatement(r))
+	}
+
+	if sql := strings.TrimSpace(r.SQL); len(sql) > 0 {
+		statements = append(statements, sql)
+	}
+
+	for _, g := range r.Grants {
+		statements = append(statements, g.grantSQL())
+	}
+
+	return strings.Join(statements, "\n")
+}
+
+// roleOptions renders the WITH clause options shared by CREATE ROLE
+// (PostgreSQL) and CREATE USER (Redshift).
+func roleOptions(r *roleEntry) string {
+	opts := []string{boolOpt(r.Login, "LOGIN", "NOLOGIN")}
+	opts = append(opts, boolOpt(r.Superuser, "SUPERUSER", "NOSUPERUSER"))
+	opts = append(opts, boolOpt(r.CreateDB, "CREATEDB", "NOCREATEDB"))
+	opts = append(opts, boolOpt(r.CreateRole, "CREATEROLE", "NOCREATEROLE"))
+	opts = append(opts, boolOpt(r.Inherit, "INHERIT", "NOINHERIT"))
+	opts = append(opts, boolOpt(r.Replication, "REPLICATION", "NOREPLICATION"))
+	opts = append(opts, boolOpt(r.BypassRLS, "BYPASSRLS", "NOBYPASSRLS"))
+	opts = append(opts, fmt.Sprintf("CONNECTION LIMIT %d", r.ConnectionLimit))
+
+	if r.Login {
+		passwordKeyword := "PASSWORD"
+		if r.EncryptedPassword {
+			passwordKeyword = "ENCRYPTED PASSWORD"
+		}
+		opts = append(opts, fmt.Sprintf("%s {{password}}", passwordKeyword))
+	}
+
+	if r.ValidUntil != "" {
+		opts = append(opts, "VALID UNTIL {{valid_until}}")
+	}
+
+	return strings.Join(opts, " ")
+}
+
+// redshiftRoleOptions renders the WITH clause options for Redshift's CREATE
+// USER, which accepts only a subset of PostgreSQL's CREATE ROLE options:
+// PASSWORD, CREATEDB, VALID UNTIL, and CONNECTION LIMIT. Redshift has no
+// LOGIN/NOLOGIN, SUPERUSER, CREATEROLE, INHERIT, REPLICATION, or BYPASSRLS
+// concept, and rejects CREATE USER statements that include them.
+func redshiftRoleOptions(r *roleEntry) string {
+	opts := []string{"PASSWORD {{password}}"}
+	opts = append(opts, boolOpt(r.CreateDB, "CREATEDB", "NOCREATEDB"))
+
+	if r.ValidUntil != "" {
+		opts = append(opts, "VALID UNTIL {{valid_until}}")
+	}
+
+	opts = append(opts, fmt.Sprintf("CONNECTION LIMIT %s", redshiftConnectionLimit(r.ConnectionLimit)))
+
+	return strings.Join(opts, " ")
+}
+
+// redshiftConnectionLimit renders a connection limit for Redshift's CREATE
+// USER, which accepts a positive integer or the keyword UNLIMITED -- unlike
+// PostgreSQL, it has no negative-number convention for "no limit".
+func redshiftConnectionLimit(limit int) string {
+	if limit < 0 {
+		return "UNLIMITED"
+	}
+	return fmt.Sprintf("%d", limit)
+}
+
+func boolOpt(v bool, whenTrue, whenFalse string) string {
+	if v {
+		return whenTrue
+	}
+	return whenFalse
+}
+
+// identifierPlaceholders lists the Query substitution keys that hold a SQL
+// identifier (e.g. a role name) rather than a string literal. Identifiers
+// are escaped with pq.QuoteIdentifier; everything else is escaped as a
+// single-quoted literal with pq.QuoteLiteral. Templates should not
+// hand-quote these placeholders themselves -- Query always emits a safely
+// quoted value.
+var identifierPlaceholders = map[string]bool{
+	"name": true,
+}
+
+// Query templates a query for us, safely escaping each substitution
+// according to the role it plays in the statement: identifiers (like
+// "{{name}}") are quoted with pq.QuoteIdentifier, and literals (like
+// "{{password}}" or "{{expiration}}") are quoted with pq.QuoteLiteral. This
+// prevents a generated username or password that contains a quote,
+// semicolon, or backslash from escaping its place in the statement.
+//
+// Templates written before this quoting was automatic wrapped placeholders
+// in their own quotes (e.g. "{{name}}", '{{expiration}}'). To keep those
+// roles' stored "sql" working unmodified, Query strips a placeholder's
+// surrounding quotes before substituting, rather than double-quoting it.
+func Query(tpl string, data map[string]string) string {
+	for k, v := range data {
+		var escaped string
+		if identifierPlaceholders[k] {
+			escaped = pq.QuoteIdentifier(v)
+		} else {
+			escaped = pq.QuoteLiteral(v)
+		}
+
+		placeholder := fmt.Sprintf("{{%s}}", k)
+		tpl = strings.Replace(tpl, `"`+placeholder+`"`, escaped, -1)
+		tpl = strings.Replace(tpl, `'`+placeholder+`'`, escaped, -1)
+		tpl = strings.Replace(tpl, placeholder, escaped, -1)
+	}
+
+	return tpl
 }
 
 const pathRoleHelpSyn = `
@@ -169,25 +755,81 @@ Manage the roles that can be created with this backend.
 const pathRoleHelpDesc = `
 This path lets you manage the roles that can be created with this backend.
 
+Writing to roles/<name> for the first time creates the role from whichever
+fields are given, applying their defaults for anything omitted. Writing to
+it again updates only the fields included in the request -- so, for
+instance, changing "db_name" on an existing role does not require
+resupplying "sql" or any of the other fields.
+
 The "sql" parameter customizes the SQL string used to create the role.
 This can be a sequence of SQL queries. Some substitution will be done to the
 SQL string for certain keys. The names of the variables must be surrounded
-by "{{" and "}}" to be replaced.
+by "{{" and "}}" to be replaced. Each placeholder is substituted as a safely
+quoted SQL identifier or literal, so it should NOT be wrapped in quotes in
+the template. For compatibility with roles written before this quoting was
+automatic, a placeholder already wrapped in its own quotes (e.g.
+"{{name}}", '{{expiration}}') is also recognized -- the existing quotes are
+replaced rather than doubled up.
 
-  * "name" - The random username generated for the DB user.
+  * "name" - The random username generated for the DB user. Quoted as an
+    identifier.
 
-  * "password" - The random password generated for the DB user.
+  * "password" - The random password generated for the DB user. Quoted as
+    a string literal.
 
-  * "expiration" - The timestamp when this user will expire.
+  * "expiration" - The timestamp when this user will expire. Quoted as a
+    string literal.
 
 Example of a decent SQL query to use:
 
-	CREATE ROLE "{{name}}" WITH
+	CREATE ROLE {{name}} WITH
 	  LOGIN
-	  PASSWORD '{{password}}'
-	  VALID UNTIL '{{expiration}}';
-	GRANT ALL PRIVILEGES ON ALL TABLES IN SCHEMA public TO "{{name}}";
+	  PASSWORD {{password}}
+	  VALID UNTIL {{expiration}};
+	GRANT ALL PRIVILEGES ON ALL TABLES IN SCHEMA public TO {{name}};
 
 Note the above user would be able to access everything in schema public.
 For more complex GRANT clauses, see the PostgreSQL manual.
-`
\ No newline at end of file
+
+Alternatively, the structured role-attribute fields ("login", "superuser",
+"create_database", "create_role", "inherit", "replication",
+"bypass_row_level_security", "connection_limit", "valid_until", and
+"encrypted_password") can be used instead of hand-writing the CREATE ROLE
+statement. When any of these are set, a CREATE ROLE statement is synthesized
+and run before "sql", so "sql" can be reserved for GRANTs and other
+follow-up statements.
+
+The "revocation_sql" and "renewal_sql" parameters override the default
+revoke (DROP ROLE) and renew (no-op) behavior. They accept the same
+"{{name}}" substitution as "sql", and "renewal_sql" additionally accepts
+"{{expiration}}". This is useful for databases like Redshift that lack true
+lease semantics and instead require something like:
+
+	ALTER USER {{name}} VALID UNTIL {{expiration}};
+
+The "grants" parameter offers a declarative alternative to writing GRANT
+statements by hand. It takes a list of objects of the form:
+
+	{
+	  "privileges": ["SELECT", "INSERT"],
+	  "on": "TABLES",
+	  "schema": "public",
+	  "objects": ["*"],
+	  "with_grant_option": false
+	}
+
+"on" may be "TABLES", "SEQUENCES", "SCHEMA", or "DATABASE". "objects" may be
+"*" to mean all objects of that kind in "schema". Each entry compiles to a
+GRANT statement appended after "sql", and to a matching REVOKE statement
+that is run automatically on lease revocation, before "revocation_sql" (or
+the driver's default), so revoking a lease undoes exactly what creating it
+granted. These REVOKEs are stored separately and are not part of the
+"revocation_sql" value read back from roles/<name>.
+
+If the role's "db_name" connection is configured with "type" set to
+"redshift" (see config/connection), the structured attributes above compile
+to a CREATE USER statement instead of CREATE ROLE, and "renewal_sql" and
+"revocation_sql" default to statements that work on Redshift. The
+"md5_password" parameter sends the password as "md5" plus the hex MD5 of
+password+username, which Redshift accepts in place of a plaintext password.
+`
@@ -0,0 +1,415 @@
+package database
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+)
+
+// fakeStorage is a minimal in-memory logical.Storage, enough to exercise
+// path callbacks that read/write through req.Storage without a real Vault
+// backend harness.
+type fakeStorage struct {
+	data map[string]*logical.StorageEntry
+}
+
+func newFakeStorage() *fakeStorage {
+	return &fakeStorage{data: make(map[string]*logical.StorageEntry)}
+}
+
+func (f *fakeStorage) Get(key string) (*logical.StorageEntry, error) {
+	return f.data[key], nil
+}
+
+func (f *fakeStorage) Put(entry *logical.StorageEntry) error {
+	f.data[entry.Key] = entry
+	return nil
+}
+
+func (f *fakeStorage) Delete(key string) error {
+	delete(f.data, key)
+	return nil
+}
+
+func (f *fakeStorage) List(prefix string) ([]string, error) {
+	var keys []string
+	for k := range f.data {
+		if strings.HasPrefix(k, prefix) {
+			keys = append(keys, k)
+		}
+	}
+	return keys, nil
+}
+
+func TestQuery_escapesIdentifiersAndLiterals(t *testing.T) {
+	cases := []struct {
+		name     string
+		password string
+		want     string
+	}{
+		{name: `foo"bar`, password: `pass'word`, want: `CREATE ROLE "foo""bar" WITH LOGIN PASSWORD 'pass''word';`},
+		{name: `foo'bar`, password: `pass"word`, want: `CREATE ROLE "foo'bar" WITH LOGIN PASSWORD 'pass"word';`},
+		{name: `foo;DROP TABLE users;--`, password: `pass;word`, want: `CREATE ROLE "foo;DROP TABLE users;--" WITH LOGIN PASSWORD 'pass;word';`},
+		{name: `foo\bar`, password: `pass\word`, want: `CREATE ROLE "foo\bar" WITH LOGIN PASSWORD  E'pass\\word';`},
+	}
+
+	for _, tc := range cases {
+		tpl := `CREATE ROLE {{name}} WITH LOGIN PASSWORD {{password}};`
+		result := Query(tpl, map[string]string{
+			"name":     tc.name,
+			"password": tc.password,
+		})
+
+		if strings.Contains(result, "{{name}}") || strings.Contains(result, "{{password}}") {
+			t.Fatalf("placeholder left unsubstituted: %s", result)
+		}
+
+		// The dangerous characters must appear only inside the escaped,
+		// quoted value -- never positioned to break out of it and inject
+		// a second statement or escalate to the surrounding SQL.
+		if result != tc.want {
+			t.Fatalf("identifier injection was not escaped:\n got: %s\nwant: %s", result, tc.want)
+		}
+	}
+}
+
+func TestQuery_identifierUsesDoubleQuotes(t *testing.T) {
+	result := Query(`DROP ROLE {{name}};`, map[string]string{"name": `o"hare`})
+
+	if !strings.HasPrefix(result, `DROP ROLE "o""hare"`) {
+		t.Fatalf("expected doubled double-quote identifier escaping, got: %s", result)
+	}
+}
+
+func TestQuery_literalUsesSingleQuotes(t *testing.T) {
+	result := Query(`SELECT {{password}};`, map[string]string{"password": `o'hare`})
+
+	if !strings.Contains(result, `'o''hare'`) {
+		t.Fatalf("expected doubled single-quote literal escaping, got: %s", result)
+	}
+}
+
+// TestQuery_toleratesPreQuotedPlaceholders guards against upgrade breakage
+// for roles whose stored "sql" was written before Query auto-quoted
+// placeholders, and so wrapped them in quotes itself (e.g. "{{name}}",
+// '{{expiration}}'). Query must recognize and strip that pre-existing
+// quoting rather than doubling up on it.
+func TestQuery_toleratesPreQuotedPlaceholders(t *testing.T) {
+	tpl := `CREATE ROLE "{{name}}" WITH LOGIN PASSWORD '{{password}}' VALID UNTIL '{{expiration}}';`
+	result := Query(tpl, map[string]string{
+		"name":       "foo",
+		"password":   "bar",
+		"expiration": "2030-01-01T00:00:00Z",
+	})
+
+	want := `CREATE ROLE "foo" WITH LOGIN PASSWORD 'bar' VALID UNTIL '2030-01-01T00:00:00Z';`
+	if result != want {
+		t.Fatalf("expected pre-existing quotes to be reused, not doubled:\n got: %s\nwant: %s", result, want)
+	}
+}
+
+// TestRoleOptions_escapesValidUntil guards against VALID UNTIL being
+// hand-quoted instead of routed through Query's literal escaping: an
+// operator-supplied valid_until containing a quote must not be able to
+// break out of the generated statement.
+func TestRoleOptions_escapesValidUntil(t *testing.T) {
+	role := &roleEntry{
+		Login:           true,
+		Inherit:         true,
+		ConnectionLimit: -1,
+		ValidUntil:      `2024-01-01'; DROP TABLE users; --`,
+	}
+
+	tpl := postgresDriver{}.CreateStatement(role)
+	if !strings.Contains(tpl, "VALID UNTIL {{valid_until}}") {
+		t.Fatalf("expected an unquoted {{valid_until}} placeholder, got: %s", tpl)
+	}
+
+	result := Query(tpl, map[string]string{
+		"name":        "foo",
+		"password":    "bar",
+		"valid_until": role.ValidUntil,
+	})
+
+	if strings.Contains(result, "{{valid_until}}") {
+		t.Fatalf("placeholder left unsubstituted: %s", result)
+	}
+	// The injected text may only appear inside the quoted literal.
+	quoted := `'2024-01-01''; DROP TABLE users; --'`
+	if strings.Contains(strings.Replace(result, quoted, "", 1), "DROP TABLE") {
+		t.Fatalf("valid_until injection was not escaped: %s", result)
+	}
+	if !strings.Contains(result, quoted) {
+		t.Fatalf("expected valid_until quoted as a doubled-quote literal, got: %s", result)
+	}
+}
+
+func TestParseGrants_compilesGrantAndRevokeSQL(t *testing.T) {
+	grants, err := parseGrants([]interface{}{
+		map[string]interface{}{
+			"privileges": []interface{}{"select", "insert"},
+			"on":         "TABLES",
+			"schema":     "app",
+			"objects":    []interface{}{"*"},
+		},
+		map[string]interface{}{
+			"privileges":        []interface{}{"usage"},
+			"on":                "SCHEMA",
+			"schema":            "app",
+			"with_grant_option": true,
+		},
+	})
+	if err != nil {
+		t.Fatalf("parseGrants returned error: %s", err)
+	}
+	if len(grants) != 2 {
+		t.Fatalf("expected 2 grants, got %d", len(grants))
+	}
+
+	wantGrant := `GRANT SELECT, INSERT ON ALL TABLES IN SCHEMA "app" TO {{name}};`
+	if got := grants[0].grantSQL(); got != wantGrant {
+		t.Fatalf("grantSQL mismatch:\n got: %s\nwant: %s", got, wantGrant)
+	}
+
+	wantRevoke := `REVOKE SELECT, INSERT ON ALL TABLES IN SCHEMA "app" FROM {{name}};`
+	if got := grants[0].revokeSQL(); got != wantRevoke {
+		t.Fatalf("revokeSQL mismatch:\n got: %s\nwant: %s", got, wantRevoke)
+	}
+
+	wantGrantOption := `GRANT USAGE ON SCHEMA "app" TO {{name}} WITH GRANT OPTION;`
+	if got := grants[1].grantSQL(); got != wantGrantOption {
+		t.Fatalf("grantSQL with_grant_option mismatch:\n got: %s\nwant: %s", got, wantGrantOption)
+	}
+
+	// Revokes are compiled in reverse order, so the schema USAGE revoke
+	// (granted second) is undone before the table privileges it depends on.
+	revokes := compileGrantRevokes(grants)
+	if !strings.HasPrefix(revokes, `REVOKE USAGE ON SCHEMA "app" FROM {{name}};`) {
+		t.Fatalf("expected revokes in reverse order, got: %s", revokes)
+	}
+}
+
+// TestParseGrants_enumeratedObjectsUseSingularKeyword guards against the
+// plural "TABLES"/"SEQUENCES" keyword leaking into the enumerated-object
+// form, where only "TABLE"/"SEQUENCE" is valid PostgreSQL.
+func TestParseGrants_enumeratedObjectsUseSingularKeyword(t *testing.T) {
+	grants, err := parseGrants([]interface{}{
+		map[string]interface{}{
+			"privileges": []interface{}{"select"},
+			"on":         "TABLES",
+			"schema":     "app",
+			"objects":    []interface{}{"mytable"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("parseGrants returned error: %s", err)
+	}
+
+	wantGrant := `GRANT SELECT ON TABLE "app"."mytable" TO {{name}};`
+	if got := grants[0].grantSQL(); got != wantGrant {
+		t.Fatalf("grantSQL mismatch:\n got: %s\nwant: %s", got, wantGrant)
+	}
+
+	wantRevoke := `REVOKE SELECT ON TABLE "app"."mytable" FROM {{name}};`
+	if got := grants[0].revokeSQL(); got != wantRevoke {
+		t.Fatalf("revokeSQL mismatch:\n got: %s\nwant: %s", got, wantRevoke)
+	}
+}
+
+// TestEffectiveRevocationSQL_fallsBackToDriverDefault guards against a role
+// that sets "grants" but no "revocation_sql" losing its DROP ROLE/USER --
+// EffectiveRevocationSQL must fall back to the driver's default revocation
+// statement, not just the compiled grant REVOKEs, or every such lease
+// revocation leaks the generated database user.
+func TestEffectiveRevocationSQL_fallsBackToDriverDefault(t *testing.T) {
+	role := &roleEntry{}
+
+	grants, err := parseGrants([]interface{}{
+		map[string]interface{}{
+			"privileges": []interface{}{"select"},
+			"on":         "TABLES",
+			"schema":     "app",
+			"objects":    []interface{}{"*"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("parseGrants returned error: %s", err)
+	}
+	role.Grants = grants
+	applyGrantRevokes(role)
+
+	driverDefault := "DROP ROLE IF EXISTS {{name}};"
+	effective := role.EffectiveRevocationSQL(driverDefault)
+
+	wantRevoke := `REVOKE SELECT ON ALL TABLES IN SCHEMA "app" FROM {{name}};`
+	if !strings.Contains(effective, wantRevoke) {
+		t.Fatalf("expected grant revoke in effective SQL, got: %s", effective)
+	}
+	if !strings.Contains(effective, driverDefault) {
+		t.Fatalf("expected driver default revocation SQL to be included, got: %s", effective)
+	}
+}
+
+// TestApplyGrantRevokes_secondUpdateDoesNotDuplicate guards against
+// GrantRevocationSQL accumulating a stale copy of a prior set of grants'
+// REVOKEs when grants are updated more than once.
+func TestApplyGrantRevokes_secondUpdateDoesNotDuplicate(t *testing.T) {
+	role := &roleEntry{}
+
+	firstGrants, err := parseGrants([]interface{}{
+		map[string]interface{}{
+			"privileges": []interface{}{"select"},
+			"on":         "TABLES",
+			"schema":     "app",
+			"objects":    []interface{}{"*"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("parseGrants returned error: %s", err)
+	}
+	role.Grants = firstGrants
+	applyGrantRevokes(role)
+	role.RevocationSQL = "DROP ROLE IF EXISTS {{name}};"
+
+	firstRevoke := `REVOKE SELECT ON ALL TABLES IN SCHEMA "app" FROM {{name}};`
+	if got := role.EffectiveRevocationSQL(""); strings.Count(got, firstRevoke) != 1 {
+		t.Fatalf("expected exactly one copy of the first revoke, got: %s", got)
+	}
+
+	secondGrants, err := parseGrants([]interface{}{
+		map[string]interface{}{
+			"privileges": []interface{}{"usage"},
+			"on":         "SCHEMA",
+			"schema":     "app",
+		},
+	})
+	if err != nil {
+		t.Fatalf("parseGrants returned error: %s", err)
+	}
+	role.Grants = secondGrants
+	applyGrantRevokes(role)
+
+	effective := role.EffectiveRevocationSQL("")
+	if strings.Contains(effective, firstRevoke) {
+		t.Fatalf("expected the first grant's revoke to be replaced, not retained: %s", effective)
+	}
+	secondRevoke := `REVOKE USAGE ON SCHEMA "app" FROM {{name}};`
+	if strings.Count(effective, secondRevoke) != 1 {
+		t.Fatalf("expected exactly one copy of the second revoke, got: %s", effective)
+	}
+	if !strings.Contains(effective, "DROP ROLE IF EXISTS {{name}};") {
+		t.Fatalf("expected hand-written revocation_sql to be preserved, got: %s", effective)
+	}
+}
+
+// TestPathRoleRead_returnsRawRevocationSQL guards against pathRoleRead
+// returning the combined EffectiveRevocationSQL (grant REVOKEs + user SQL)
+// under the "revocation_sql" key -- a read-modify-write that resends both
+// that value and "grants" would otherwise duplicate the grant REVOKEs,
+// defeating applyGrantRevokes' anti-accumulation fix on the read-back path.
+func TestPathRoleRead_returnsRawRevocationSQL(t *testing.T) {
+	s := newFakeStorage()
+
+	role := &roleEntry{RevocationSQL: "DROP ROLE IF EXISTS {{name}};"}
+	grants, err := parseGrants([]interface{}{
+		map[string]interface{}{
+			"privileges": []interface{}{"select"},
+			"on":         "TABLES",
+			"schema":     "app",
+			"objects":    []interface{}{"*"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("parseGrants returned error: %s", err)
+	}
+	role.Grants = grants
+	applyGrantRevokes(role)
+
+	entry, err := logical.StorageEntryJSON("role/myrole", role)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if err := s.Put(entry); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	data := &framework.FieldData{
+		Raw:    map[string]interface{}{"name": "myrole"},
+		Schema: pathRoles(nil).Fields,
+	}
+
+	resp, err := (*backend)(nil).pathRoleRead(&logical.Request{Storage: s}, data)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	got := resp.Data["revocation_sql"].(string)
+	if got != role.RevocationSQL {
+		t.Fatalf("expected raw revocation_sql %q, got: %q", role.RevocationSQL, got)
+	}
+}
+
+// TestPathRoleExistenceCheck_routesCreateVsUpdate exercises the routing
+// decision itself -- not just applyRoleField in isolation -- so a missing
+// ExistenceCheck (which would leave every write landing on UpdateOperation,
+// and "no role found to update" for a role that has never been created)
+// would fail this test.
+func TestPathRoleExistenceCheck_routesCreateVsUpdate(t *testing.T) {
+	s := newFakeStorage()
+	data := &framework.FieldData{
+		Raw:    map[string]interface{}{"name": "myrole"},
+		Schema: pathRoles(nil).Fields,
+	}
+
+	exists, err := (*backend)(nil).pathRoleExistenceCheck(&logical.Request{Storage: s}, data)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if exists {
+		t.Fatalf("expected no role to exist before it has been written")
+	}
+
+	entry, err := logical.StorageEntryJSON("role/myrole", &roleEntry{SQL: `CREATE ROLE {{name}};`})
+	if err != nil {
+		t.Fatalf("failed to build storage entry: %s", err)
+	}
+	if err := s.Put(entry); err != nil {
+		t.Fatalf("failed to store role: %s", err)
+	}
+
+	exists, err = (*backend)(nil).pathRoleExistenceCheck(&logical.Request{Storage: s}, data)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !exists {
+		t.Fatalf("expected the stored role to be found on the second write")
+	}
+}
+
+// TestPathRoleUpdate_mergesOnlyPresentFields replays pathRoleUpdate's
+// field-merge loop over roleScalarFields directly; it does not call
+// pathRoleUpdate, which also validates the role against a live connection.
+func TestPathRoleUpdate_mergesOnlyPresentFields(t *testing.T) {
+	schema := pathRoles(nil).Fields
+
+	role := &roleEntry{SQL: `CREATE ROLE {{name}};`, DBName: "old-db"}
+
+	// Only "db_name" is present on this update request.
+	raw := map[string]interface{}{"db_name": "new-db"}
+	data := &framework.FieldData{Raw: raw, Schema: schema}
+
+	for _, field := range roleScalarFields {
+		if _, ok := data.Raw[field]; ok {
+			applyRoleField(role, data, field)
+		}
+	}
+
+	if role.DBName != "new-db" {
+		t.Fatalf("expected db_name to be updated, got: %s", role.DBName)
+	}
+	if role.SQL != `CREATE ROLE {{name}};` {
+		t.Fatalf("expected sql to be left untouched, got: %s", role.SQL)
+	}
+}
@@ -0,0 +1,186 @@
+package database
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/vault/helper/strutil"
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+)
+
+const SecretCredsType = "creds"
+
+func secretCreds(b *backend) *framework.Secret {
+	return &framework.Secret{
+		Type: SecretCredsType,
+		Fields: map[string]*framework.FieldSchema{
+			"username": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: "Username",
+			},
+
+			"password": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: "Password",
+			},
+		},
+
+		Renew:  b.secretCredsRenew,
+		Revoke: b.secretCredsRevoke,
+	}
+}
+
+func (b *backend) secretCredsRenew(
+	req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	usernameRaw, ok := req.Secret.InternalData["username"]
+	if !ok {
+		return nil, fmt.Errorf("secret is missing username internal data")
+	}
+	username, ok := usernameRaw.(string)
+	if !ok {
+		return nil, fmt.Errorf("secret is missing username internal data")
+	}
+
+	role, err := b.roleForSecret(req)
+	if err != nil {
+		return nil, err
+	}
+
+	f := framework.LeaseExtend(req.Secret.Increment, req.Secret.MaxIncrement, b.System())
+	resp, err := f(req, data)
+	if err != nil {
+		return nil, err
+	}
+
+	if role == nil {
+		// No role to consult for driver-specific default behavior; extend
+		// the lease only.
+		return resp, nil
+	}
+
+	drv, err := b.driverForRole(req.Storage, role)
+	if err != nil {
+		return nil, err
+	}
+
+	renewalSQL := drv.DefaultRenewalSQL()
+	if strings.TrimSpace(role.RenewalSQL) != "" {
+		renewalSQL = role.RenewalSQL
+	}
+	if strings.TrimSpace(renewalSQL) == "" {
+		// Default behavior: extend the lease only; there is no SQL to
+		// communicate the new expiration to the database.
+		return resp, nil
+	}
+
+	dbconn, err := b.DBConnection(req.Storage, role.DBName)
+	if err != nil {
+		return nil, err
+	}
+
+	expiration := time.Now().Add(resp.Secret.TTL).Format("2006-01-02 15:04:05-0700")
+
+	for _, query := range strutil.ParseArbitraryStringSlice(renewalSQL, ";") {
+		query = strings.TrimSpace(query)
+		if len(query) == 0 {
+			continue
+		}
+
+		stmt, err := dbconn.Prepare(Query(query, map[string]string{
+			"name":       username,
+			"expiration": expiration,
+		}))
+		if err != nil {
+			return nil, err
+		}
+		defer stmt.Close()
+
+		if _, err := stmt.Exec(); err != nil {
+			return nil, err
+		}
+	}
+
+	return resp, nil
+}
+
+func (b *backend) secretCredsRevoke(
+	req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	usernameRaw, ok := req.Secret.InternalData["username"]
+	if !ok {
+		return nil, fmt.Errorf("secret is missing username internal data")
+	}
+	username, ok := usernameRaw.(string)
+	if !ok {
+		return nil, fmt.Errorf("secret is missing username internal data")
+	}
+
+	role, err := b.roleForSecret(req)
+	if err != nil {
+		return nil, err
+	}
+
+	var dbName string
+	if role != nil {
+		dbName = role.DBName
+	} else if v, ok := req.Secret.InternalData["db_name"].(string); ok {
+		dbName = v
+	}
+
+	dbconn, err := b.DBConnection(req.Storage, dbName)
+	if err != nil {
+		return nil, err
+	}
+
+	drv, err := b.driverForDB(req.Storage, dbName)
+	if err != nil {
+		return nil, err
+	}
+
+	revocationSQL := drv.DefaultRevocationSQL()
+	if role != nil {
+		// EffectiveRevocationSQL falls back to drv.DefaultRevocationSQL()
+		// itself when the role has no hand-written RevocationSQL, so the
+		// driver's DROP ROLE/USER always runs alongside any grant REVOKEs --
+		// a role with only "grants" set does not leak the generated user.
+		revocationSQL = role.EffectiveRevocationSQL(drv.DefaultRevocationSQL())
+	}
+
+	for _, query := range strutil.ParseArbitraryStringSlice(revocationSQL, ";") {
+		query = strings.TrimSpace(query)
+		if len(query) == 0 {
+			continue
+		}
+
+		stmt, err := dbconn.Prepare(Query(query, map[string]string{
+			"name": username,
+		}))
+		if err != nil {
+			return nil, err
+		}
+		defer stmt.Close()
+
+		if _, err := stmt.Exec(); err != nil {
+			return nil, err
+		}
+	}
+
+	return nil, nil
+}
+
+// roleForSecret looks up the roleEntry that produced this secret, if the
+// role still exists. A missing role is not an error: the lease still needs
+// to be revoked or renewed using default behavior.
+func (b *backend) roleForSecret(req *logical.Request) (*roleEntry, error) {
+	roleRaw, ok := req.Secret.InternalData["role"]
+	if !ok {
+		return nil, nil
+	}
+	roleName, ok := roleRaw.(string)
+	if !ok || roleName == "" {
+		return nil, nil
+	}
+
+	return b.Role(req.Storage, roleName)
+}